@@ -0,0 +1,201 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/taubyte/go-sdk/database"
+	baseEvent "github.com/taubyte/go-sdk/event"
+)
+
+type batchOperation struct {
+	Op     string `json:"op"`
+	PeerID string `json:"peerId"`
+	// Payload is kept as raw JSON rather than a typed *valuePayload so that
+	// (a) a per-item type mismatch can't abort unmarshalling of the whole
+	// batch, and (b) migrateLegacyValues - which sniffs for a "limits" key -
+	// can run against the bytes the caller actually sent.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type batchRequest struct {
+	Operations []batchOperation `json:"operations"`
+	DryRun     bool             `json:"dryRun"`
+}
+
+type batchResult struct {
+	PeerID   string        `json:"peerId"`
+	Status   string        `json:"status"`
+	Code     int           `json:"code"`
+	Error    string        `json:"error,omitempty"`
+	Value    *valuePayload `json:"value,omitempty"`
+	Migrated bool          `json:"migrated,omitempty"`
+	Changed  bool          `json:"changed,omitempty"`
+}
+
+// ---------- Batch Handler ----------
+
+//export batchValues
+func batchValues(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	body, err := io.ReadAll(h.Body())
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to read request body"), 400)
+	}
+
+	var request batchRequest
+	if err = json.Unmarshal(body, &request); err != nil {
+		return handleHTTPError(h, fmt.Errorf("invalid payload format"), 400)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	results := make([]batchResult, 0, len(request.Operations))
+	for _, op := range request.Operations {
+		results = append(results, runBatchOperation(db, op, request.DryRun))
+	}
+
+	return sendJSONResponse(h, map[string]interface{}{
+		"results": results,
+	})
+}
+
+func runBatchOperation(db database.Database, op batchOperation, dryRun bool) batchResult {
+	peerID := strings.TrimSpace(op.PeerID)
+
+	switch op.Op {
+	case "upsert":
+		return runBatchUpsert(db, peerID, op.Payload, dryRun)
+	case "delete":
+		return runBatchDelete(db, peerID, dryRun)
+	case "get":
+		return runBatchGet(db, peerID)
+	default:
+		return batchResult{PeerID: peerID, Status: "error", Code: 400, Error: fmt.Sprintf("unknown op %q", op.Op)}
+	}
+}
+
+func runBatchUpsert(db database.Database, peerID string, raw json.RawMessage, dryRun bool) batchResult {
+	if len(raw) == 0 {
+		return batchResult{PeerID: peerID, Status: "error", Code: 400, Error: "payload is required"}
+	}
+
+	var payload valuePayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 400, Error: "invalid payload format"}
+	}
+
+	if strings.TrimSpace(payload.PeerID) == "" {
+		payload.PeerID = peerID
+	}
+
+	migrated := false
+	if len(payload.Values) == 0 {
+		if values, ok := migrateLegacyValues(raw); ok {
+			payload.Values = values
+			migrated = true
+		}
+	}
+
+	if err := validateValuePayload(payload); err != nil {
+		return batchResult{PeerID: payload.PeerID, Status: "error", Code: 400, Error: err.Error()}
+	}
+
+	values, changed, err := normaliseValues(payload.Values)
+	if err != nil {
+		return batchResult{PeerID: payload.PeerID, Status: "error", Code: 400, Error: err.Error()}
+	}
+	metric := values[metricKey]
+	payload.Values = map[string]valueMetrics{metricKey: metric}
+
+	if dryRun {
+		return batchResult{
+			PeerID:   payload.PeerID,
+			Status:   "ok",
+			Code:     200,
+			Value:    &payload,
+			Migrated: migrated,
+			Changed:  changed,
+		}
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return batchResult{PeerID: payload.PeerID, Status: "error", Code: 500, Error: "failed to encode payload"}
+	}
+
+	key := "/peer/" + payload.PeerID
+	if err = db.Put(key, payloadJSON); err != nil {
+		return batchResult{PeerID: payload.PeerID, Status: "error", Code: 500, Error: "failed to store value"}
+	}
+
+	if err = appendHistorySample(db, payload.PeerID, metric, time.Now().Unix()); err != nil {
+		return batchResult{PeerID: payload.PeerID, Status: "error", Code: 500, Error: "failed to record history sample"}
+	}
+	appendChangelogEntry(db, streamOpUpsert, payload)
+
+	return batchResult{PeerID: payload.PeerID, Status: "ok", Code: 200, Value: &payload, Migrated: migrated, Changed: changed}
+}
+
+func runBatchDelete(db database.Database, peerID string, dryRun bool) batchResult {
+	if peerID == "" {
+		return batchResult{PeerID: peerID, Status: "error", Code: 400, Error: "peerId is required"}
+	}
+
+	key, data, err := findValueByPeerID(db, peerID)
+	if err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 404, Error: "value not found"}
+	}
+
+	payload, _, err := decodeValuePayload(data, key)
+	if err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 500, Error: "stored value is invalid"}
+	}
+	if payload.Origin != "" {
+		return batchResult{PeerID: peerID, Status: "error", Code: 409, Error: fmt.Sprintf("value originates from remote peer %s and cannot be deleted locally", payload.Origin)}
+	}
+
+	if dryRun {
+		return batchResult{PeerID: peerID, Status: "ok", Code: 200, Value: &payload}
+	}
+
+	if err = db.Delete(key); err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 500, Error: "failed to delete value"}
+	}
+	appendChangelogEntry(db, streamOpDelete, payload)
+
+	return batchResult{PeerID: peerID, Status: "ok", Code: 200}
+}
+
+func runBatchGet(db database.Database, peerID string) batchResult {
+	if peerID == "" {
+		return batchResult{PeerID: peerID, Status: "error", Code: 400, Error: "peerId is required"}
+	}
+
+	key, data, err := findValueByPeerID(db, peerID)
+	if err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 404, Error: "value not found"}
+	}
+
+	payload, _, err := decodeValuePayload(data, key)
+	if err != nil {
+		return batchResult{PeerID: peerID, Status: "error", Code: 500, Error: "stored value is invalid"}
+	}
+
+	return batchResult{PeerID: peerID, Status: "ok", Code: 200, Value: &payload}
+}
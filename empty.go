@@ -7,6 +7,7 @@ import (
 	"io"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/taubyte/go-sdk/database"
 	baseEvent "github.com/taubyte/go-sdk/event"
@@ -33,6 +34,7 @@ type valuePayload struct {
 	Address valueAddress            `json:"address"`
 	Values  map[string]valueMetrics `json:"values"`
 	Raw     string                  `json:"raw"`
+	Origin  string                  `json:"origin,omitempty"`
 }
 
 type valueMetrics struct {
@@ -323,20 +325,82 @@ func listValues(e baseEvent.Event) uint32 {
 		return 0
 	}
 
+	query := h.Query()
+	scope, _ := query.Get("scope")
+	sortRaw, _ := query.Get("sort")
+	cursorRaw, _ := query.Get("cursor")
+	filterRaw, _ := query.Get("filter")
+	limit := int(parseQueryInt(query, "limit", listDefaultLimit))
+	if limit <= 0 || limit > listMaxLimit {
+		limit = listDefaultLimit
+	}
+
+	sortSpec, err := parseListSort(sortRaw)
+	if err != nil {
+		return handleHTTPError(h, err, 400)
+	}
+	filters, err := parseListFilters(filterRaw)
+	if err != nil {
+		return handleHTTPError(h, err, 400)
+	}
+
 	db, err := openDB()
 	if err != nil {
 		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
 	}
 	defer db.Close()
 
-	keys, err := db.List("/peer")
+	// scope=all additionally returns federated values (Origin != ""), which
+	// is exactly what syncPeeringRemote pulls from a peer's listValues - gate
+	// it behind the peering secret so that shared secret actually controls
+	// access to something, rather than only being sent on the wire.
+	if scope == "all" {
+		peeringSecret, _ := query.Get("peeringSecret")
+		if !peeringSecretAuthorized(db, peeringSecret) {
+			return handleHTTPError(h, fmt.Errorf("peeringSecret is required for scope=all"), 403)
+		}
+	}
+
+	var boundary *listEntry
+	if cursorRaw != "" {
+		cursor, err := decodeListCursor(cursorRaw)
+		if err != nil {
+			return handleHTTPError(h, err, 400)
+		}
+		if cursor.Sort != sortRaw {
+			return handleHTTPError(h, fmt.Errorf("cursor does not match the requested sort"), 400)
+		}
+		data, err := db.Get(cursor.LastKey)
+		if err != nil {
+			return handleHTTPError(h, fmt.Errorf("cursor is no longer valid"), 400)
+		}
+		payload, _, err := decodeValuePayload(data, cursor.LastKey)
+		if err != nil {
+			return handleHTTPError(h, fmt.Errorf("cursor is no longer valid"), 400)
+		}
+		boundary = &listEntry{Key: cursor.LastKey, Payload: payload}
+	}
+
+	// List with a trailing slash so the prefix only matches peer records
+	// (e.g. "/peer/abc") and not unrelated trees such as "/peering/...".
+	keys, err := db.List("/peer/")
 	if err != nil {
 		return handleHTTPError(h, fmt.Errorf("failed to list values"), 500)
 	}
 
-	values := make([]valuePayload, 0, len(keys))
+	pruneChangelogIfDue(db, time.Now().Unix())
+
+	// Entries are folded into page directly as each key is read, so memory
+	// stays bounded by limit instead of growing with the number of matches
+	// (see listPageBuilder).
+	page := newListPageBuilder(sortSpec, limit)
+	total := 0
 	for _, key := range keys {
 		id := key
+		if isHistoryKey(id) || isChangelogKey(id) {
+			continue
+		}
+
 		data, err := db.Get(id)
 		if err != nil {
 			return handleHTTPError(h, fmt.Errorf("failed to read value for key %s", id), 500)
@@ -357,12 +421,53 @@ func listValues(e baseEvent.Event) uint32 {
 			}
 		}
 
-		values = append(values, payload)
+		if payload.Origin != "" && scope != "all" {
+			continue
+		}
+
+		sweepHistoryIfDue(db, payload.PeerID, time.Now().Unix())
+
+		matched := true
+		for _, filter := range filters {
+			ok, err := matchesListFilter(payload, filter)
+			if err != nil {
+				return handleHTTPError(h, err, 400)
+			}
+			if !ok {
+				matched = false
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		total++
+
+		entry := listEntry{Key: id, Payload: payload}
+		if boundary != nil && !listEntryRankLess(*boundary, entry, sortSpec) {
+			continue
+		}
+		page.add(entry)
+	}
+
+	pageEntries, hasMore := page.finish()
+
+	values := make([]valuePayload, 0, len(pageEntries))
+	for _, entry := range pageEntries {
+		values = append(values, entry.Payload)
+	}
+
+	nextCursor := ""
+	if hasMore && len(pageEntries) > 0 {
+		nextCursor = encodeListCursor(pageEntries[len(pageEntries)-1].Key, sortRaw)
 	}
 
 	return sendJSONResponse(h, map[string]interface{}{
-		"count":  len(values),
-		"values": values,
+		"count":      len(values),
+		"total":      total,
+		"nextCursor": nextCursor,
+		"values":     values,
 	})
 }
 
@@ -426,6 +531,11 @@ func registerValue(e baseEvent.Event) uint32 {
 		return handleHTTPError(h, fmt.Errorf("failed to store value"), 500)
 	}
 
+	if err = appendHistorySample(db, peerID, metric, time.Now().Unix()); err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to record history sample"), 500)
+	}
+	appendChangelogEntry(db, streamOpUpsert, payload)
+
 	return sendJSONResponse(h, map[string]string{
 		"peerId": peerID,
 		"status": "created",
@@ -502,7 +612,7 @@ func deleteValue(e baseEvent.Event) uint32 {
 	}
 	defer db.Close()
 
-	key, _, err := findValueByPeerID(db, peerID)
+	key, data, err := findValueByPeerID(db, peerID)
 	if err != nil {
 		if errors.Is(err, errValueNotFound) {
 			return handleHTTPError(h, err, 404)
@@ -510,9 +620,18 @@ func deleteValue(e baseEvent.Event) uint32 {
 		return handleHTTPError(h, fmt.Errorf("failed to read value"), 500)
 	}
 
+	payload, _, err := decodeValuePayload(data, key)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("stored value is invalid: %w", err), 500)
+	}
+	if payload.Origin != "" {
+		return handleHTTPError(h, fmt.Errorf("value %s originates from remote peer %s and cannot be deleted locally", peerID, payload.Origin), 409)
+	}
+
 	if err = db.Delete(key); err != nil {
 		return handleHTTPError(h, fmt.Errorf("failed to delete value"), 500)
 	}
+	appendChangelogEntry(db, streamOpDelete, payload)
 
 	return sendJSONResponse(h, map[string]string{
 		"peerId": peerID,
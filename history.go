@@ -0,0 +1,276 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/taubyte/go-sdk/database"
+	baseEvent "github.com/taubyte/go-sdk/event"
+)
+
+// History key layout
+//
+// Every successful registerValue appends a sample to a rolling history kept
+// under:
+//
+//	/peer/<peerId>/history/<bucket>
+//
+// where <bucket> is the sample timestamp aligned down to historyBucketSeconds
+// and rendered as a fixed-width, zero-padded decimal (historyBucketWidth
+// digits). Fixed width is what makes db.List("/peer/<id>/history/") come
+// back in lexicographically increasing, i.e. chronological, order - the
+// getValueHistory and sweep code below both depend on that ordering and must
+// not be changed to a variable-width encoding.
+//
+// /peer/<peerId>/history/_meta holds the unix timestamp of the last
+// retention sweep for that peer, so listValues can throttle sweeps instead
+// of running one on every request.
+const (
+	historyBucketSeconds       = int64(60)
+	historyBucketWidth         = 12
+	historyRetentionSeconds    = int64(7 * 24 * 3600)
+	historySweepIntervalSecond = int64(300)
+)
+
+type historySample struct {
+	TS        int64   `json:"ts"`
+	Current   float64 `json:"current"`
+	SoftLimit float64 `json:"softLimit"`
+	HardLimit float64 `json:"hardLimit"`
+}
+
+type historyBucket struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	Last  float64 `json:"last"`
+	TS    int64   `json:"ts"`
+	count int64
+}
+
+func historyPrefix(peerID string) string {
+	return "/peer/" + peerID + "/history/"
+}
+
+func historyMetaKey(peerID string) string {
+	return historyPrefix(peerID) + "_meta"
+}
+
+func historyBucketAlign(ts, resolution int64) int64 {
+	if resolution <= 0 {
+		resolution = historyBucketSeconds
+	}
+	return ts - (ts % resolution)
+}
+
+func historyBucketKey(peerID string, bucket int64) string {
+	return fmt.Sprintf("%s%0*d", historyPrefix(peerID), historyBucketWidth, bucket)
+}
+
+// isHistoryKey reports whether key is one of the internal history entries
+// this file writes under /peer/<peerId>/history/<bucket-or-_meta>. It checks
+// for a "history" path *segment* followed by a digit bucket or "_meta", not
+// a loose substring match - a peerId like "fleet/historyNode" must not be
+// mistaken for a history key just because "/history" appears inside it.
+func isHistoryKey(key string) bool {
+	segments := strings.Split(strings.TrimPrefix(key, "/peer/"), "/")
+	for i := 0; i < len(segments)-1; i++ {
+		if segments[i] != "history" {
+			continue
+		}
+		if suffix := segments[i+1]; suffix == "_meta" || isDigits(suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func appendHistorySample(db database.Database, peerID string, metric valueMetrics, ts int64) error {
+	bucket := historyBucketAlign(ts, historyBucketSeconds)
+	key := historyBucketKey(peerID, bucket)
+
+	var samples []historySample
+	if data, err := db.Get(key); err == nil {
+		_ = json.Unmarshal(data, &samples)
+	}
+
+	samples = append(samples, historySample{
+		TS:        ts,
+		Current:   metric.Current,
+		SoftLimit: metric.SoftLimit,
+		HardLimit: metric.HardLimit,
+	})
+
+	data, err := json.Marshal(samples)
+	if err != nil {
+		return fmt.Errorf("failed to encode history sample: %w", err)
+	}
+
+	return db.Put(key, data)
+}
+
+// sweepHistoryIfDue deletes history buckets older than historyRetentionSeconds
+// for peerID, at most once every historySweepIntervalSecond. Failures are
+// swallowed: retention is best-effort and must never break listValues.
+func sweepHistoryIfDue(db database.Database, peerID string, now int64) {
+	metaKey := historyMetaKey(peerID)
+
+	if data, err := db.Get(metaKey); err == nil {
+		if lastSweep, parseErr := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); parseErr == nil {
+			if now-lastSweep < historySweepIntervalSecond {
+				return
+			}
+		}
+	}
+
+	keys, err := db.List(historyPrefix(peerID))
+	if err == nil {
+		cutoff := historyBucketAlign(now-historyRetentionSeconds, historyBucketSeconds)
+		for _, key := range keys {
+			if strings.HasSuffix(key, "_meta") {
+				continue
+			}
+			bucketStr := key[strings.LastIndex(key, "/")+1:]
+			bucket, parseErr := strconv.ParseInt(bucketStr, 10, 64)
+			if parseErr != nil {
+				continue
+			}
+			if bucket < cutoff {
+				_ = db.Delete(key)
+			}
+		}
+	}
+
+	_ = db.Put(metaKey, []byte(strconv.FormatInt(now, 10)))
+}
+
+// ---------- History Handler ----------
+
+//export getValueHistory
+func getValueHistory(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	query := h.Query()
+
+	peerID, _ := query.Get("peerId")
+	peerID = strings.TrimSpace(peerID)
+	if peerID == "" {
+		return handleHTTPError(h, fmt.Errorf("peerId is required"), 400)
+	}
+
+	from := parseQueryInt(query, "from", 0)
+	to := parseQueryInt(query, "to", 1<<62)
+	resolution := parseQueryInt(query, "resolution", historyBucketSeconds)
+	if resolution <= 0 {
+		resolution = historyBucketSeconds
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	keys, err := db.List(historyPrefix(peerID))
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to list history"), 500)
+	}
+
+	order := make([]int64, 0)
+	buckets := make(map[int64]*historyBucket)
+
+	for _, key := range keys {
+		if strings.HasSuffix(key, "_meta") {
+			continue
+		}
+
+		data, err := db.Get(key)
+		if err != nil {
+			continue
+		}
+
+		var samples []historySample
+		if err := json.Unmarshal(data, &samples); err != nil {
+			continue
+		}
+
+		for _, sample := range samples {
+			if sample.TS < from || sample.TS > to {
+				continue
+			}
+
+			outBucket := historyBucketAlign(sample.TS, resolution)
+			agg, ok := buckets[outBucket]
+			if !ok {
+				agg = &historyBucket{Min: sample.Current, Max: sample.Current, TS: outBucket}
+				buckets[outBucket] = agg
+				order = append(order, outBucket)
+			}
+
+			if sample.Current < agg.Min {
+				agg.Min = sample.Current
+			}
+			if sample.Current > agg.Max {
+				agg.Max = sample.Current
+			}
+			agg.Avg = runningAverage(agg.Avg, sample.Current, agg)
+			agg.Last = sample.Current
+		}
+	}
+
+	series := make([]historyBucket, 0, len(order))
+	for _, ts := range order {
+		series = append(series, *buckets[ts])
+	}
+
+	return sendJSONResponse(h, map[string]interface{}{
+		"peerId":     peerID,
+		"from":       from,
+		"to":         to,
+		"resolution": resolution,
+		"samples":    series,
+	})
+}
+
+// runningAverage folds a new sample into agg's running average. It first
+// increments agg.count, the unexported per-bucket sample count declared on
+// historyBucket, and uses it as the divisor.
+func runningAverage(currentAvg, next float64, agg *historyBucket) float64 {
+	agg.count++
+	return currentAvg + (next-currentAvg)/float64(agg.count)
+}
+
+func parseQueryInt(query interface {
+	Get(string) (string, error)
+}, name string, def int64) int64 {
+	raw, err := query.Get(name)
+	if err != nil || strings.TrimSpace(raw) == "" {
+		return def
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,288 @@
+package lib
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	listDefaultLimit = 50
+	listMaxLimit     = 500
+)
+
+var listSortFields = map[string]bool{
+	"peerId":    true,
+	"current":   true,
+	"softLimit": true,
+	"hardLimit": true,
+}
+
+var listFilterFields = map[string]bool{
+	"peerId":     true,
+	"address.ip": true,
+	"current":    true,
+	"softLimit":  true,
+	"hardLimit":  true,
+}
+
+type listSortSpec struct {
+	Field string
+	Desc  bool
+}
+
+type listFilterExpr struct {
+	Field string
+	Op    string
+	Value string
+}
+
+type listCursor struct {
+	LastKey string `json:"lastKey"`
+	Sort    string `json:"sortSnapshot"`
+}
+
+type listEntry struct {
+	Key     string
+	Payload valuePayload
+}
+
+func parseListSort(raw string) (listSortSpec, error) {
+	spec := listSortSpec{Field: "peerId"}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return spec, nil
+	}
+
+	if strings.HasPrefix(raw, "-") {
+		spec.Desc = true
+		raw = raw[1:]
+	}
+	if !listSortFields[raw] {
+		return spec, fmt.Errorf("sort must be one of peerId, current, softLimit, hardLimit (optionally prefixed with -)")
+	}
+	spec.Field = raw
+	return spec, nil
+}
+
+// parseListFilters splits the raw filter query value on commas, since the
+// underlying SDK query API only exposes a single value per parameter name -
+// so ?filter=a:eq:b,c:gt:1 is how callers pass more than one expression.
+func parseListFilters(raw string) ([]listFilterExpr, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, nil
+	}
+
+	var filters []listFilterExpr
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		segments := strings.SplitN(part, ":", 3)
+		if len(segments) != 3 {
+			return nil, fmt.Errorf("filter %q must have the form field:op:value", part)
+		}
+
+		field, op, value := segments[0], segments[1], segments[2]
+		if !listFilterFields[field] {
+			return nil, fmt.Errorf("filter field %q is not filterable", field)
+		}
+
+		switch op {
+		case "eq", "lt", "lte", "gt", "gte":
+		case "contains":
+			if field != "peerId" && field != "address.ip" {
+				return nil, fmt.Errorf("filter op \"contains\" only applies to peerId and address.ip")
+			}
+		default:
+			return nil, fmt.Errorf("filter op %q is not supported", op)
+		}
+
+		filters = append(filters, listFilterExpr{Field: field, Op: op, Value: value})
+	}
+
+	return filters, nil
+}
+
+func listFieldValue(payload valuePayload, field string) (numeric float64, str string, isNumeric bool) {
+	switch field {
+	case "peerId":
+		return 0, payload.PeerID, false
+	case "address.ip":
+		return 0, payload.Address.IP, false
+	case "current":
+		return payload.Values[metricKey].Current, "", true
+	case "softLimit":
+		return payload.Values[metricKey].SoftLimit, "", true
+	case "hardLimit":
+		return payload.Values[metricKey].HardLimit, "", true
+	}
+	return 0, "", false
+}
+
+func matchesListFilter(payload valuePayload, f listFilterExpr) (bool, error) {
+	numeric, str, isNumeric := listFieldValue(payload, f.Field)
+
+	if isNumeric {
+		value, err := strconv.ParseFloat(f.Value, 64)
+		if err != nil {
+			return false, fmt.Errorf("filter value %q for %s must be numeric", f.Value, f.Field)
+		}
+		switch f.Op {
+		case "eq":
+			return numeric == value, nil
+		case "lt":
+			return numeric < value, nil
+		case "lte":
+			return numeric <= value, nil
+		case "gt":
+			return numeric > value, nil
+		case "gte":
+			return numeric >= value, nil
+		}
+		return false, fmt.Errorf("filter op %q is not supported for %s", f.Op, f.Field)
+	}
+
+	switch f.Op {
+	case "eq":
+		return str == f.Value, nil
+	case "lt":
+		return str < f.Value, nil
+	case "lte":
+		return str <= f.Value, nil
+	case "gt":
+		return str > f.Value, nil
+	case "gte":
+		return str >= f.Value, nil
+	case "contains":
+		return strings.Contains(str, f.Value), nil
+	}
+	return false, fmt.Errorf("filter op %q is not supported for %s", f.Op, f.Field)
+}
+
+// listEntryRankLess reports whether a sorts before b under spec, tie-breaking
+// on key so the ordering - and therefore pagination - is stable.
+func listEntryRankLess(a, b listEntry, spec listSortSpec) bool {
+	aNum, aStr, numeric := listFieldValue(a.Payload, spec.Field)
+	less := false
+	equal := false
+	if numeric {
+		bNum, _, _ := listFieldValue(b.Payload, spec.Field)
+		less = aNum < bNum
+		equal = aNum == bNum
+	} else {
+		_, bStr, _ := listFieldValue(b.Payload, spec.Field)
+		less = aStr < bStr
+		equal = aStr == bStr
+	}
+
+	if spec.Desc {
+		if !equal {
+			return !less
+		}
+	} else if !equal {
+		return less
+	}
+
+	return a.Key < b.Key
+}
+
+// listEntryHeap is a bounded max-heap over the "worst" (last-ranked, per
+// listEntryRankLess) entries seen so far, used to keep only the top `limit`
+// candidates in O(n log limit) instead of fully sorting every match.
+type listEntryHeap struct {
+	entries []listEntry
+	spec    listSortSpec
+}
+
+func (h listEntryHeap) Len() int { return len(h.entries) }
+func (h listEntryHeap) Less(i, j int) bool {
+	// Reversed so the heap root is the entry that ranks *last* among those
+	// kept - i.e. the first one we evict when a better candidate shows up.
+	return listEntryRankLess(h.entries[j], h.entries[i], h.spec)
+}
+func (h listEntryHeap) Swap(i, j int) { h.entries[i], h.entries[j] = h.entries[j], h.entries[i] }
+func (h *listEntryHeap) Push(x interface{}) {
+	h.entries = append(h.entries, x.(listEntry))
+}
+func (h *listEntryHeap) Pop() interface{} {
+	old := h.entries
+	n := len(old)
+	item := old[n-1]
+	h.entries = old[:n-1]
+	return item
+}
+
+// listPageBuilder accumulates the best-ranked entries for a page one at a
+// time via add, so a caller streaming Get per key (as listValues does) never
+// materialises more than `limit` entries at once - memory stays bounded by
+// the page size instead of growing with the number of matches.
+type listPageBuilder struct {
+	heap  listEntryHeap
+	limit int
+	seen  int
+}
+
+func newListPageBuilder(spec listSortSpec, limit int) *listPageBuilder {
+	return &listPageBuilder{heap: listEntryHeap{spec: spec}, limit: limit}
+}
+
+// add folds entry into the page, keeping only the limit best-ranked entries
+// seen so far.
+func (b *listPageBuilder) add(entry listEntry) {
+	b.seen++
+	if b.limit <= 0 {
+		b.heap.entries = append(b.heap.entries, entry)
+		return
+	}
+	if b.heap.Len() < b.limit {
+		heap.Push(&b.heap, entry)
+		return
+	}
+	// heap.entries[0] is the worst-ranked kept entry; replace it if this
+	// one ranks better.
+	if listEntryRankLess(entry, b.heap.entries[0], b.heap.spec) {
+		b.heap.entries[0] = entry
+		heap.Fix(&b.heap, 0)
+	}
+}
+
+// finish returns the accumulated entries in final sorted order, plus whether
+// more entries were added than fit in the page.
+func (b *listPageBuilder) finish() ([]listEntry, bool) {
+	ordered := make([]listEntry, len(b.heap.entries))
+	copy(ordered, b.heap.entries)
+	sortListEntries(ordered, b.heap.spec)
+	hasMore := b.limit > 0 && b.seen > b.limit
+	return ordered, hasMore
+}
+
+func sortListEntries(entries []listEntry, spec listSortSpec) {
+	sort.Slice(entries, func(i, j int) bool {
+		return listEntryRankLess(entries[i], entries[j], spec)
+	})
+}
+
+func encodeListCursor(key, sortRaw string) string {
+	data, _ := json.Marshal(listCursor{LastKey: key, Sort: sortRaw})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(raw string) (listCursor, error) {
+	var cursor listCursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor, fmt.Errorf("cursor is malformed")
+	}
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("cursor is malformed")
+	}
+	return cursor, nil
+}
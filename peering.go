@@ -0,0 +1,406 @@
+package lib
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/taubyte/go-sdk/database"
+	baseEvent "github.com/taubyte/go-sdk/event"
+	httpClient "github.com/taubyte/go-sdk/http/client"
+)
+
+const peeringTokenTTLDefault = int64(3600)
+
+// peeringToken is the payload handed out by generatePeeringToken. The opaque
+// token string is base64(json(peeringToken)) + "." + base64(hmac), where the
+// HMAC key is Secret itself rather than a key private to the issuing
+// instance - two independent deployments never share a persisted signing
+// key, so the only way establishPeering on a *different* instance can verify
+// a token is if everything needed to verify it travels inside the token.
+// The signature still catches a corrupted or truncated token; it isn't meant
+// to stop someone who already holds the token from using it, since holding
+// the token is itself the proof of authorization here.
+type peeringToken struct {
+	ID        string `json:"id"`
+	TargetURL string `json:"targetUrl"`
+	Secret    string `json:"secret"`
+	ExpiresAt int64  `json:"expiresAt"`
+}
+
+// peeringRemote tracks the state of a peering relationship established via
+// establishPeering and advanced by syncPeeringRemote. Secret is the shared
+// secret from the peering token, used to authenticate outbound sync calls;
+// it is stripped before the record is ever sent back in an HTTP response.
+type peeringRemote struct {
+	ID         string `json:"id"`
+	RemoteURL  string `json:"remoteUrl"`
+	Secret     string `json:"secret,omitempty"`
+	Status     string `json:"status"` // pending, active, failed
+	LastSyncAt int64  `json:"lastSyncAt"`
+	LastError  string `json:"lastError,omitempty"`
+}
+
+// withoutSecret returns a copy of remote safe to hand back to an API caller.
+func (remote peeringRemote) withoutSecret() peeringRemote {
+	remote.Secret = ""
+	return remote
+}
+
+func signPeeringToken(payload []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	signature := mac.Sum(nil)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// verifyPeeringToken checks a token's signature and expiry using only what
+// the token itself carries, so it works regardless of which instance issued
+// it - see the peeringToken doc comment for why that's required.
+func verifyPeeringToken(token string) (peeringToken, error) {
+	var parsed peeringToken
+
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return parsed, fmt.Errorf("malformed peering token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return parsed, fmt.Errorf("malformed peering token")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return parsed, fmt.Errorf("malformed peering token")
+	}
+
+	if err = json.Unmarshal(payload, &parsed); err != nil {
+		return parsed, fmt.Errorf("malformed peering token")
+	}
+
+	mac := hmac.New(sha256.New, []byte(parsed.Secret))
+	mac.Write(payload)
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return parsed, fmt.Errorf("peering token signature is invalid")
+	}
+
+	if parsed.ExpiresAt <= time.Now().Unix() {
+		return parsed, fmt.Errorf("peering token has expired")
+	}
+
+	return parsed, nil
+}
+
+// peeringSecretAuthorized reports whether secret matches a live (unexpired)
+// token this instance has issued via generatePeeringToken. listValues gates
+// scope=all (federated) results behind this, since the shared secret exists
+// specifically to authorize pulling this instance's values for sync.
+func peeringSecretAuthorized(db database.Database, secret string) bool {
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		return false
+	}
+
+	ids, err := db.List("/peering/tokens/")
+	if err != nil {
+		return false
+	}
+
+	now := time.Now().Unix()
+	for _, id := range ids {
+		data, err := db.Get(id)
+		if err != nil {
+			continue
+		}
+		var token peeringToken
+		if err := json.Unmarshal(data, &token); err != nil {
+			continue
+		}
+		if token.ExpiresAt <= now {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(token.Secret), []byte(secret)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func randomPeeringID() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate peering id")
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+// ---------- Peering Handlers ----------
+
+//export generatePeeringToken
+func generatePeeringToken(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	body, err := io.ReadAll(h.Body())
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to read request body"), 400)
+	}
+
+	var request struct {
+		TargetURL  string `json:"targetUrl"`
+		TTLSeconds int64  `json:"ttlSeconds"`
+	}
+	if err = json.Unmarshal(body, &request); err != nil {
+		return handleHTTPError(h, fmt.Errorf("invalid payload format"), 400)
+	}
+	if strings.TrimSpace(request.TargetURL) == "" {
+		return handleHTTPError(h, fmt.Errorf("targetUrl is required"), 400)
+	}
+
+	ttl := request.TTLSeconds
+	if ttl <= 0 {
+		ttl = peeringTokenTTLDefault
+	}
+
+	id, err := randomPeeringID()
+	if err != nil {
+		return handleHTTPError(h, err, 500)
+	}
+	secret, err := randomPeeringID()
+	if err != nil {
+		return handleHTTPError(h, err, 500)
+	}
+
+	claims := peeringToken{
+		ID:        id,
+		TargetURL: request.TargetURL,
+		Secret:    secret,
+		ExpiresAt: time.Now().Unix() + ttl,
+	}
+
+	claimsJSON, err := json.Marshal(claims)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to encode peering token"), 500)
+	}
+	token := signPeeringToken(claimsJSON, claims.Secret)
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	if err = db.Put("/peering/tokens/"+id, claimsJSON); err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to store peering token"), 500)
+	}
+
+	return sendJSONResponse(h, map[string]interface{}{
+		"id":        id,
+		"token":     token,
+		"expiresAt": claims.ExpiresAt,
+	})
+}
+
+//export establishPeering
+func establishPeering(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	body, err := io.ReadAll(h.Body())
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to read request body"), 400)
+	}
+
+	var request struct {
+		Token string `json:"token"`
+	}
+	if err = json.Unmarshal(body, &request); err != nil {
+		return handleHTTPError(h, fmt.Errorf("invalid payload format"), 400)
+	}
+	if strings.TrimSpace(request.Token) == "" {
+		return handleHTTPError(h, fmt.Errorf("token is required"), 400)
+	}
+
+	claims, err := verifyPeeringToken(request.Token)
+	if err != nil {
+		return handleHTTPError(h, err, 400)
+	}
+
+	remote := peeringRemote{
+		ID:        claims.ID,
+		RemoteURL: claims.TargetURL,
+		Secret:    claims.Secret,
+		Status:    "pending",
+	}
+	remoteJSON, err := json.Marshal(remote)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to encode peering remote"), 500)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	if err = db.Put("/peering/remotes/"+remote.ID, remoteJSON); err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to store peering remote"), 500)
+	}
+
+	return sendJSONResponse(h, remote.withoutSecret())
+}
+
+//export syncPeeringRemote
+func syncPeeringRemote(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	remoteID, err := getPeerIDFromPath(h)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("missing remote id"), 400)
+	}
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	remoteKey := "/peering/remotes/" + remoteID
+	data, err := db.Get(remoteKey)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("peering remote not found"), 404)
+	}
+
+	var remote peeringRemote
+	if err = json.Unmarshal(data, &remote); err != nil {
+		return handleHTTPError(h, fmt.Errorf("stored peering remote is invalid"), 500)
+	}
+
+	synced, syncErr := fetchRemoteValues(remote.RemoteURL, remote.Secret)
+	var itemErrors []string
+	if syncErr != nil {
+		remote.Status = "failed"
+		remote.LastError = syncErr.Error()
+	} else {
+		for _, value := range synced {
+			value.Origin = remote.ID
+
+			if err := validateValuePayload(value); err != nil {
+				itemErrors = append(itemErrors, fmt.Sprintf("%s: %s", value.PeerID, err))
+				continue
+			}
+			values, _, err := normaliseValues(value.Values)
+			if err != nil {
+				itemErrors = append(itemErrors, fmt.Sprintf("%s: %s", value.PeerID, err))
+				continue
+			}
+			value.Values = values
+
+			valueJSON, err := json.Marshal(value)
+			if err != nil {
+				itemErrors = append(itemErrors, fmt.Sprintf("%s: failed to encode", value.PeerID))
+				continue
+			}
+			if err = db.Put("/peer/"+remote.ID+"/"+value.PeerID, valueJSON); err != nil {
+				itemErrors = append(itemErrors, fmt.Sprintf("%s: failed to store", value.PeerID))
+				continue
+			}
+		}
+
+		remote.Status = "active"
+		remote.LastSyncAt = time.Now().Unix()
+		if len(itemErrors) > 0 {
+			remote.LastError = fmt.Sprintf("%d of %d values were rejected: %s", len(itemErrors), len(synced), strings.Join(itemErrors, "; "))
+		} else {
+			remote.LastError = ""
+		}
+	}
+
+	remoteJSON, err := json.Marshal(remote)
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to encode peering remote"), 500)
+	}
+	if err = db.Put(remoteKey, remoteJSON); err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to update peering remote"), 500)
+	}
+
+	if syncErr != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to sync peering remote: %w", syncErr), 502)
+	}
+
+	return sendJSONResponse(h, remote.withoutSecret())
+}
+
+// fetchRemoteValues pulls the listValues response from a remote seguentedb
+// instance, authenticating with the shared secret from the peering token.
+// The secret travels as a peeringSecret query parameter rather than an
+// Authorization header: like streamValues' lastEventId, there's no incoming
+// header read anywhere in this package, only Headers().Set on the response
+// side, so the query string is the only channel a handler can actually
+// inspect. listValues' peeringSecretAuthorized check is the other half.
+// Outbound calls go through the go-sdk http client rather than net/http:
+// these functions run as sandboxed WASM modules with no stdlib socket stack.
+func fetchRemoteValues(remoteURL, secret string) ([]valuePayload, error) {
+	separator := "?"
+	if strings.Contains(remoteURL, "?") {
+		separator = "&"
+	}
+	requestURL := fmt.Sprintf("%s%sscope=all&peeringSecret=%s", remoteURL, separator, url.QueryEscape(secret))
+
+	req, err := httpClient.New(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create remote request: %w", err)
+	}
+
+	resp, err := req.Send()
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach remote: %w", err)
+	}
+	defer resp.Body().Close()
+
+	if resp.StatusCode() != 200 {
+		return nil, fmt.Errorf("remote returned status %d", resp.StatusCode())
+	}
+
+	body, err := io.ReadAll(resp.Body())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote response: %w", err)
+	}
+
+	var listResponse struct {
+		Values []valuePayload `json:"values"`
+	}
+	if err = json.Unmarshal(body, &listResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode remote response: %w", err)
+	}
+
+	return listResponse.Values, nil
+}
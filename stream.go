@@ -0,0 +1,278 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/taubyte/go-sdk/database"
+	baseEvent "github.com/taubyte/go-sdk/event"
+)
+
+// Changelog key layout
+//
+// registerValue and deleteValue append an entry under:
+//
+//	/peer/_changelog/<seq>
+//
+// where <seq> is a monotonically increasing counter (tracked at
+// /peer/_changelog/_seq) rendered as a fixed-width, zero-padded decimal so
+// db.List("/peer/_changelog/") comes back in the order entries were written.
+// streamValues uses that ordering to resume after a client's Last-Event-ID.
+const (
+	changelogSeqWidth          = 20
+	changelogSeqKey            = "/peer/_changelog/_seq"
+	changelogPruneMetaKey      = "/peer/_changelog/_prunemeta"
+	changelogRetentionSeconds  = int64(3600)
+	changelogPruneIntervalSecs = int64(300)
+
+	streamPollInterval       = 500 * time.Millisecond
+	streamDefaultMaxDuration = 25 * time.Second
+	streamMaxDurationCap     = 120 * time.Second
+	streamOpUpsert           = "upsert"
+	streamOpDelete           = "delete"
+)
+
+type changelogPayload struct {
+	Current   float64 `json:"current"`
+	SoftLimit float64 `json:"softLimit"`
+	HardLimit float64 `json:"hardLimit"`
+}
+
+type changelogEntry struct {
+	Seq     int64             `json:"seq"`
+	TS      int64             `json:"ts"`
+	Op      string            `json:"op"`
+	PeerID  string            `json:"peerId"`
+	Payload *changelogPayload `json:"payload,omitempty"`
+}
+
+// isChangelogKey reports whether key is one of the internal entries this
+// file writes under the reserved /peer/_changelog/ peer id, matched as an
+// exact leading path segment rather than a loose substring - a peerId like
+// "teams/_changelogger" must not be mistaken for a changelog key.
+func isChangelogKey(key string) bool {
+	segments := strings.SplitN(strings.TrimPrefix(key, "/peer/"), "/", 2)
+	return len(segments) > 0 && segments[0] == "_changelog"
+}
+
+func changelogKey(seq int64) string {
+	return fmt.Sprintf("/peer/_changelog/%0*d", changelogSeqWidth, seq)
+}
+
+func nextChangelogSeq(db database.Database) (int64, error) {
+	seq := int64(0)
+	if data, err := db.Get(changelogSeqKey); err == nil {
+		if parsed, parseErr := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); parseErr == nil {
+			seq = parsed
+		}
+	}
+	seq++
+	if err := db.Put(changelogSeqKey, []byte(strconv.FormatInt(seq, 10))); err != nil {
+		return 0, fmt.Errorf("failed to advance changelog sequence: %w", err)
+	}
+	return seq, nil
+}
+
+// appendChangelogEntry records a registerValue/deleteValue mutation so
+// streamValues subscribers can pick it up without polling the CRUD
+// endpoints. Failures here must not fail the mutation itself.
+func appendChangelogEntry(db database.Database, op string, payload valuePayload) {
+	seq, err := nextChangelogSeq(db)
+	if err != nil {
+		return
+	}
+
+	entry := changelogEntry{
+		Seq:    seq,
+		TS:     time.Now().Unix(),
+		Op:     op,
+		PeerID: payload.PeerID,
+	}
+	if op == streamOpUpsert {
+		metric := payload.Values[metricKey]
+		entry.Payload = &changelogPayload{
+			Current:   metric.Current,
+			SoftLimit: metric.SoftLimit,
+			HardLimit: metric.HardLimit,
+		}
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = db.Put(changelogKey(seq), data)
+}
+
+// pruneChangelogIfDue deletes changelog entries older than
+// changelogRetentionSeconds, at most once every changelogPruneIntervalSecs.
+func pruneChangelogIfDue(db database.Database, now int64) {
+	if data, err := db.Get(changelogPruneMetaKey); err == nil {
+		if lastPrune, parseErr := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64); parseErr == nil {
+			if now-lastPrune < changelogPruneIntervalSecs {
+				return
+			}
+		}
+	}
+
+	keys, err := db.List("/peer/_changelog/")
+	if err == nil {
+		for _, key := range keys {
+			if strings.HasSuffix(key, "_seq") || strings.HasSuffix(key, "_prunemeta") {
+				continue
+			}
+			data, err := db.Get(key)
+			if err != nil {
+				continue
+			}
+			var entry changelogEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				continue
+			}
+			if now-entry.TS > changelogRetentionSeconds {
+				_ = db.Delete(key)
+			}
+		}
+	}
+
+	_ = db.Put(changelogPruneMetaKey, []byte(strconv.FormatInt(now, 10)))
+}
+
+func latestChangelogSeq(db database.Database) int64 {
+	data, err := db.Get(changelogSeqKey)
+	if err != nil {
+		return 0
+	}
+	seq, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return seq
+}
+
+func changelogEntriesAfter(db database.Database, afterSeq int64) ([]changelogEntry, error) {
+	keys, err := db.List("/peer/_changelog/")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]changelogEntry, 0)
+	for _, key := range keys {
+		if strings.HasSuffix(key, "_seq") || strings.HasSuffix(key, "_prunemeta") {
+			continue
+		}
+		data, err := db.Get(key)
+		if err != nil {
+			continue
+		}
+		var entry changelogEntry
+		if err := json.Unmarshal(data, &entry); err != nil {
+			continue
+		}
+		if entry.Seq > afterSeq {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func appendSSEFrame(buf *strings.Builder, entry changelogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "id: %d\nevent: %s\ndata: %s\n\n", entry.Seq, entry.Op, data)
+	return nil
+}
+
+// ---------- Streaming Handler ----------
+
+//export streamValues
+func streamValues(e baseEvent.Event) uint32 {
+	h, err := e.HTTP()
+	if err != nil {
+		return 1
+	}
+	setCORSHeaders(h)
+	if isPreflight(h) {
+		return 0
+	}
+
+	query := h.Query()
+	peerFilter, _ := query.Get("peerId")
+	minCurrent := parseQueryInt(query, "minCurrent", -1)
+	maxDuration := time.Duration(parseQueryInt(query, "maxDurationSeconds", int64(streamDefaultMaxDuration/time.Second))) * time.Second
+	if maxDuration <= 0 || maxDuration > streamMaxDurationCap {
+		maxDuration = streamDefaultMaxDuration
+	}
+
+	// The go-sdk http event only exposes response headers for writing, so
+	// resumption relies on the query-string form of Last-Event-ID.
+	lastEventID, _ := query.Get("lastEventId")
+
+	db, err := openDB()
+	if err != nil {
+		return handleHTTPError(h, fmt.Errorf("failed to open database"), 500)
+	}
+	defer db.Close()
+
+	afterSeq := latestChangelogSeq(db)
+	if parsed, err := strconv.ParseInt(strings.TrimSpace(lastEventID), 10, 64); err == nil {
+		afterSeq = parsed
+	}
+
+	h.Headers().Set("Content-Type", "text/event-stream")
+	h.Headers().Set("Cache-Control", "no-cache")
+	h.Headers().Set("Connection", "keep-alive")
+
+	// Every other handler in this package writes its response body exactly
+	// once, immediately before its single Return call - that's the event
+	// API's contract, and nothing suggests Write is flushed progressively
+	// mid-handler. So rather than interleave Write with time.Sleep, we poll
+	// the changelog in memory and buffer every frame, then make one Write
+	// followed by one Return. Once this response ends the client resumes by
+	// reconnecting with the last frame's id as the lastEventId query
+	// parameter; a Last-Event-ID request header is not read.
+	//
+	// Note this request-scoped handler has no way to observe the client
+	// disconnecting early - the go-sdk http event exposes no such hook. The
+	// loop returns as soon as a poll has buffered at least one frame, and
+	// otherwise when max duration elapses.
+	var buf strings.Builder
+	deadline := time.Now().Add(maxDuration)
+	for time.Now().Before(deadline) {
+		entries, err := changelogEntriesAfter(db, afterSeq)
+		if err != nil {
+			return handleHTTPError(h, fmt.Errorf("failed to read changelog"), 500)
+		}
+
+		for _, entry := range entries {
+			if peerFilter != "" && entry.PeerID != peerFilter {
+				continue
+			}
+			if minCurrent >= 0 && (entry.Payload == nil || int64(entry.Payload.Current) < minCurrent) {
+				continue
+			}
+			if err := appendSSEFrame(&buf, entry); err != nil {
+				return handleHTTPError(h, fmt.Errorf("failed to encode changelog entry"), 500)
+			}
+			if entry.Seq > afterSeq {
+				afterSeq = entry.Seq
+			}
+		}
+
+		if buf.Len() > 0 {
+			break
+		}
+
+		time.Sleep(streamPollInterval)
+	}
+
+	h.Write([]byte(buf.String()))
+	h.Return(200)
+	return 0
+}